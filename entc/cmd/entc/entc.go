@@ -15,6 +15,9 @@ import (
 	"text/template"
 	"unicode"
 
+	"github.com/facebookincubator/ent/entc/config"
+	"github.com/facebookincubator/ent/entc/entgql"
+	"github.com/facebookincubator/ent/entc/entk8s"
 	"github.com/facebookincubator/ent/entc/gen"
 	"github.com/facebookincubator/ent/entc/load"
 	"github.com/facebookincubator/ent/schema/field"
@@ -77,44 +80,172 @@ func main() {
 		},
 		func() *cobra.Command {
 			var (
-				cfg      gen.Config
-				storage  []string
-				template []string
-				idtype   = idType(field.TypeInt)
-				cmd      = &cobra.Command{
+				header      string
+				storage     []string
+				tmplPaths   []string
+				idtype      = idType(field.TypeInt)
+				targetFlags []string
+				configFile  string
+				profile     string
+				cmd         = &cobra.Command{
 					Use:   "generate [flags] path",
 					Short: "generate go code for the schema directory",
 					Example: examples(
 						"entc generate ./ent/schema",
-						"entc generate github.com/a8m/x",
+						"entc generate --idtype string --storage sql ./ent/schema",
+						"entc generate --config entc.yaml --profile postgres-prod ./ent/schema",
+						`entc generate --target "name=sql,path=ent/sql,storage=sql" --target "name=gremlin,path=ent/gremlin,storage=gremlin" ./ent/schema`,
 					),
 					Args: cobra.ExactArgs(1),
 					Run: func(cmd *cobra.Command, path []string) {
-						if cfg.Target == "" {
-							abs, err := filepath.Abs(path[0])
-							failOnErr(err)
-							cfg.Target = filepath.Dir(abs)
+						fc, err := config.Load(configFile)
+						failOnErr(err)
+						fc, err = fc.Apply(profile)
+						failOnErr(err)
+						if !cmd.Flags().Changed("header") {
+							header = fc.Header
 						}
-						for _, s := range storage {
-							sr, err := gen.NewStorage(s)
-							failOnErr(err)
-							cfg.Storage = append(cfg.Storage, sr)
+						if !cmd.Flags().Changed("idtype") && fc.IDType != "" {
+							failOnErr(idtype.Set(fc.IDType))
+						}
+						if !cmd.Flags().Changed("storage") {
+							storage = fc.Storage
 						}
-						if len(template) > 0 {
-							cfg.Template = loadTemplate(template)
+						if !cmd.Flags().Changed("template") {
+							tmplPaths = fc.Templates
 						}
-						cfg.IDType = &field.TypeInfo{Type: field.Type(idtype)}
-						graph, err := loadGraph(path[0], cfg)
+
+						var targets []config.Target
+						switch {
+						case len(targetFlags) == 1 && !strings.Contains(targetFlags[0], "="):
+							// Backward-compatible single-target invocation:
+							// "--target <dir>" combined with the scalar
+							// --idtype/--storage/--template flags.
+							targets = []config.Target{{
+								Name:      "default",
+								Path:      targetFlags[0],
+								Storage:   storage,
+								IDType:    field.Type(idtype).String(),
+								Templates: tmplPaths,
+							}}
+						case len(targetFlags) > 0:
+							for _, s := range targetFlags {
+								t, err := parseTarget(s)
+								failOnErr(err)
+								targets = append(targets, t)
+							}
+						case len(fc.Targets) > 0:
+							targets = fc.Targets
+						default:
+							targets = []config.Target{{
+								Name:      "default",
+								Path:      fc.Target,
+								Storage:   storage,
+								IDType:    field.Type(idtype).String(),
+								Templates: tmplPaths,
+							}}
+						}
+						if len(targets) > 1 {
+							seen := make(map[string]string, len(targets))
+							for _, t := range targets {
+								if t.Path == "" {
+									failOnErr(fmt.Errorf("target %q: path is required when declaring more than one target", t.Name))
+								}
+								if prev, ok := seen[t.Path]; ok {
+									failOnErr(fmt.Errorf("targets %q and %q both resolve to the same path %q", prev, t.Name, t.Path))
+								}
+								seen[t.Path] = t.Name
+							}
+						}
+						// Parse the schema directory once and share it
+						// across every target so we don't re-run
+						// load.Config.Load() per target.
+						spec, err := loadSpec(path[0])
 						failOnErr(err)
-						failOnErr(graph.Gen())
+						for _, t := range targets {
+							failOnErr(generateTarget(spec, path[0], header, t))
+						}
 					},
 				}
 			)
 			cmd.Flags().Var(&idtype, "idtype", "type of the id field")
-			cmd.Flags().StringVar(&cfg.Header, "header", "", "override codegen header")
-			cmd.Flags().StringVar(&cfg.Target, "target", "", "target directory for codegen")
-			cmd.Flags().StringSliceVarP(&template, "template", "", nil, "external templates to execute")
+			cmd.Flags().StringVar(&header, "header", "", "override codegen header")
+			cmd.Flags().StringSliceVarP(&tmplPaths, "template", "", nil, "external templates to execute")
 			cmd.Flags().StringSliceVarP(&storage, "storage", "", []string{"sql"}, "list of storage drivers to support")
+			cmd.Flags().StringArrayVar(&targetFlags, "target", nil,
+				`target directory for codegen, or (repeatable) an output target spec "name=sql,path=ent/sql,storage=sql,idtype=int64,template=a.tmpl;b.tmpl" to declare more than one target`)
+			cmd.Flags().StringVar(&configFile, "config", "", "path to entc.yaml config file (default ./entc.yaml)")
+			cmd.Flags().StringVar(&profile, "profile", "", "named config profile to apply (e.g. sqlite-tests, postgres-prod)")
+			return cmd
+		}(),
+		func() *cobra.Command {
+			var (
+				target string
+				relay  bool
+				cmd    = &cobra.Command{
+					Use:   "gqlgen [flags] path",
+					Short: "generate a gqlgen-compatible GraphQL schema and resolvers",
+					Example: examples(
+						"entc gqlgen ./ent/schema",
+						"entc gqlgen --relay --target internal/graph ./ent/schema",
+					),
+					Args: cobra.ExactArgs(1),
+					Run: func(cmd *cobra.Command, path []string) {
+						graph, err := loadGraph(path[0], gen.Config{})
+						failOnErr(err)
+						if target == "" {
+							abs, err := filepath.Abs(path[0])
+							failOnErr(err)
+							// path[0] is the schema directory inside the ent package.
+							target = filepath.Join(filepath.Dir(filepath.Dir(abs)), "graph")
+						}
+						failOnErr(entgql.Generate(graph, entgql.Config{
+							Target: target,
+							Relay:  relay,
+						}))
+					},
+				}
+			)
+			cmd.Flags().StringVar(&target, "target", "", "target directory for the generated schema and resolvers")
+			cmd.Flags().BoolVar(&relay, "relay", false, "generate Relay-style pagination (Node, PageInfo, cursor args)")
+			return cmd
+		}(),
+		func() *cobra.Command {
+			var (
+				target   string
+				group    string
+				version  string
+				edgeMode string
+				cmd      = &cobra.Command{
+					Use:   "k8s [flags] path",
+					Short: "generate Kubernetes CustomResourceDefinitions from the schema graph",
+					Example: examples(
+						"entc k8s ./ent/schema",
+						"entc k8s --group ent.io --version v1 --edge-mode embed ./ent/schema",
+					),
+					Args: cobra.ExactArgs(1),
+					Run: func(cmd *cobra.Command, path []string) {
+						graph, err := loadGraph(path[0], gen.Config{})
+						failOnErr(err)
+						if target == "" {
+							abs, err := filepath.Abs(path[0])
+							failOnErr(err)
+							// path[0] is the schema directory inside the ent package.
+							target = filepath.Join(filepath.Dir(filepath.Dir(abs)), "k8s")
+						}
+						failOnErr(entk8s.Generate(graph, entk8s.Config{
+							Target:   target,
+							Group:    group,
+							Version:  version,
+							EdgeMode: entk8s.EdgeMode(edgeMode),
+						}))
+					},
+				}
+			)
+			cmd.Flags().StringVar(&target, "target", "", "target directory for the generated CRD manifests")
+			cmd.Flags().StringVar(&group, "group", "ent.io", "default Kubernetes API group for types without a GroupVersionKind annotation")
+			cmd.Flags().StringVar(&version, "version", "v1", "default Kubernetes API version for types without a GroupVersionKind annotation")
+			cmd.Flags().StringVar(&edgeMode, "edge-mode", string(entk8s.EdgeModeReference), "how edges are represented: reference or embed")
 			return cmd
 		}(),
 	)
@@ -127,15 +258,104 @@ func main() {
 //
 // The second argument is an optional config for the graph creation.
 func loadGraph(path string, cfg gen.Config) (*gen.Graph, error) {
-	spec, err := (&load.Config{Path: path}).Load()
+	spec, err := loadSpec(path)
 	if err != nil {
 		return nil, err
 	}
+	return graphFromSpec(spec, cfg)
+}
+
+// loadSpec parses the schema package at path. It is the expensive,
+// I/O-bound step of loadGraph, and is shared across every target of a
+// multi-target "entc generate" run so it only runs once per path.
+func loadSpec(path string) (*load.SchemaSpec, error) {
+	return (&load.Config{Path: path}).Load()
+}
+
+// graphFromSpec builds a *gen.Graph for cfg from an already-loaded
+// schema spec, without re-parsing the schema package.
+func graphFromSpec(spec *load.SchemaSpec, cfg gen.Config) (*gen.Graph, error) {
 	cfg.Schema = spec.PkgPath
 	cfg.Package = filepath.Dir(spec.PkgPath)
 	return gen.NewGraph(cfg, spec.Schemas...)
 }
 
+// parseTarget parses a "--target" flag value into a config.Target. A
+// bare value with no "=" is shorthand for "name=default,path=<value>",
+// matching the pre-multi-target "--target <dir>" invocation. Otherwise
+// it's a comma-separated list of key=value pairs; storage and template
+// lists are ";"-separated since "," already delimits pairs.
+func parseTarget(s string) (config.Target, error) {
+	if !strings.Contains(s, "=") {
+		return config.Target{Name: "default", Path: s}, nil
+	}
+	var t config.Target
+	for _, kv := range strings.Split(s, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return config.Target{}, fmt.Errorf("invalid --target segment %q", kv)
+		}
+		k, v := parts[0], parts[1]
+		switch k {
+		case "name":
+			t.Name = v
+		case "path":
+			t.Path = v
+		case "idtype":
+			t.IDType = v
+		case "storage":
+			t.Storage = strings.Split(v, ";")
+		case "template":
+			t.Templates = strings.Split(v, ";")
+		default:
+			return config.Target{}, fmt.Errorf("unknown --target key %q", k)
+		}
+	}
+	if t.Name == "" {
+		return config.Target{}, fmt.Errorf("--target requires a name=... segment")
+	}
+	return t, nil
+}
+
+// generateTarget builds and emits the code for a single target, reusing
+// spec so the schema package isn't re-parsed per target.
+func generateTarget(spec *load.SchemaSpec, path, header string, t config.Target) error {
+	cfg := gen.Config{Header: header, Target: t.Path}
+	if cfg.Target == "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("target %q: %w", t.Name, err)
+		}
+		cfg.Target = filepath.Dir(abs)
+	}
+	idtype := idType(field.TypeInt)
+	if t.IDType != "" {
+		if err := idtype.Set(t.IDType); err != nil {
+			return fmt.Errorf("target %q: %w", t.Name, err)
+		}
+	}
+	cfg.IDType = &field.TypeInfo{Type: field.Type(idtype)}
+	storage := t.Storage
+	if len(storage) == 0 {
+		storage = []string{"sql"}
+	}
+	for _, s := range storage {
+		sr, err := gen.NewStorage(s)
+		if err != nil {
+			return fmt.Errorf("target %q: %w", t.Name, err)
+		}
+		cfg.Storage = append(cfg.Storage, sr)
+	}
+	if len(t.Templates) > 0 {
+		cfg.Template = loadTemplate(t.Templates)
+	}
+	graph, err := graphFromSpec(spec, cfg)
+	if err != nil {
+		return fmt.Errorf("target %q: %w", t.Name, err)
+	}
+	return graph.Gen()
+}
+
 // loadTemplate loads templates from files or directory.
 func loadTemplate(paths []string) *template.Template {
 	t := template.New("external").
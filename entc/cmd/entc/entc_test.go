@@ -0,0 +1,67 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/facebookincubator/ent/entc/config"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    config.Target
+		wantErr bool
+	}{
+		{
+			name: "bare path is shorthand for name=default,path=...",
+			in:   "ent/sql",
+			want: config.Target{Name: "default", Path: "ent/sql"},
+		},
+		{
+			name: "key=value pairs",
+			in:   "name=sql,path=ent/sql,idtype=int64,storage=sql;gremlin,template=a.tmpl;b.tmpl",
+			want: config.Target{
+				Name:      "sql",
+				Path:      "ent/sql",
+				IDType:    "int64",
+				Storage:   []string{"sql", "gremlin"},
+				Templates: []string{"a.tmpl", "b.tmpl"},
+			},
+		},
+		{
+			name:    "missing name is an error",
+			in:      "path=ent/sql",
+			wantErr: true,
+		},
+		{
+			name:    "unknown key is an error",
+			in:      "name=sql,bogus=1",
+			wantErr: true,
+		},
+		{
+			name:    "malformed segment is an error",
+			in:      "name=sql,storage",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTarget(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTarget(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTarget(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
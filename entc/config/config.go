@@ -0,0 +1,137 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+// Package config provides a typed representation of the entc.yaml
+// configuration file consumed by the "entc generate" command.
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// Config is the typed schema of an entc.yaml file.
+type Config struct {
+	Target    string             `mapstructure:"target"`
+	Header    string             `mapstructure:"header"`
+	IDType    string             `mapstructure:"idtype"`
+	Storage   []string           `mapstructure:"storage"`
+	Templates []string           `mapstructure:"templates"`
+	Profiles  map[string]Profile `mapstructure:"profiles"`
+	// Targets declares the independent output variants to build from
+	// the same schema directory in a single "entc generate" run, e.g.
+	// a "sql" target and a "gremlin" target. When empty, generate
+	// falls back to the single target described by Target/Storage/
+	// IDType/Templates above.
+	Targets []Target `mapstructure:"targets"`
+}
+
+// Target is one named output variant of a multi-target generate run:
+// its own package, storage drivers, id type and templates, all built
+// from the schema directory shared by every other target.
+type Target struct {
+	Name      string   `mapstructure:"name"`
+	Path      string   `mapstructure:"path"`
+	Storage   []string `mapstructure:"storage"`
+	IDType    string   `mapstructure:"idtype"`
+	Templates []string `mapstructure:"templates"`
+}
+
+// Profile overrides a subset of Config's fields for a named
+// configuration, e.g. "sqlite-tests" or "postgres-prod", so a single
+// entc.yaml can drive several codegen runs with different storage
+// drivers and templates.
+type Profile struct {
+	Target    string   `mapstructure:"target"`
+	Storage   []string `mapstructure:"storage"`
+	Templates []string `mapstructure:"templates"`
+	IDType    string   `mapstructure:"idtype"`
+}
+
+// defaults mirror the zero-value behavior of the "entc generate" flags.
+var defaults = Config{
+	IDType:  "int",
+	Storage: []string{"sql"},
+}
+
+// Load reads the entc config from path, or from ./entc.yaml if path is
+// empty, merged with ENTC_* environment variables and falling back to
+// defaults. Target, header and idtype are bound to their
+// ENTC_TARGET/ENTC_HEADER/ENTC_IDTYPE env vars; since AutomaticEnv is
+// enabled, any other key already set by a default or by the file (such
+// as storage) is also overridden by its ENTC_<KEY> env var, while keys
+// absent from both are not looked up in the environment. The result
+// follows a flag > env > file > default precedence once the caller
+// overlays any flags the user explicitly set.
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	v.SetDefault("idtype", defaults.IDType)
+	v.SetDefault("storage", defaults.Storage)
+	v.SetEnvPrefix("entc")
+	v.AutomaticEnv()
+	for _, key := range []string{"target", "header", "idtype"} {
+		if err := v.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("config: bind env %s: %w", key, err)
+		}
+	}
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("entc")
+		v.AddConfigPath(".")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		_, notFound := err.(viper.ConfigFileNotFoundError)
+		// Only a missing default (no explicit --config, and no
+		// ./entc.yaml present) falls back to defaults silently. A
+		// malformed default file, or any problem reading an explicit
+		// --config path (including it not existing), is reported.
+		if path != "" || !notFound {
+			return nil, fmt.Errorf("config: read %s: %w", configPathFor(path), err)
+		}
+	}
+	cfg := defaults
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("config: unmarshal: %w", err)
+	}
+	return &cfg, nil
+}
+
+// configPathFor returns the path Load attempted to read, for use in
+// error messages, falling back to the default filename when the
+// caller didn't pass one explicitly.
+func configPathFor(path string) string {
+	if path == "" {
+		return "entc.yaml"
+	}
+	return path
+}
+
+// Apply selects the named profile (if any) and overlays its non-zero
+// fields on top of c, returning the effective Config to use for codegen.
+// An empty name is a no-op and returns c unchanged.
+func (c *Config) Apply(profile string) (*Config, error) {
+	if profile == "" {
+		return c, nil
+	}
+	p, ok := c.Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("config: unknown profile %q", profile)
+	}
+	out := *c
+	if p.Target != "" {
+		out.Target = p.Target
+	}
+	if p.IDType != "" {
+		out.IDType = p.IDType
+	}
+	if len(p.Storage) > 0 {
+		out.Storage = p.Storage
+	}
+	if len(p.Templates) > 0 {
+		out.Templates = p.Templates
+	}
+	return &out, nil
+}
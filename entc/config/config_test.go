@@ -0,0 +1,99 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load(\"\") with no entc.yaml present: %v", err)
+	}
+	if cfg.IDType != defaults.IDType {
+		t.Errorf("IDType = %q, want default %q", cfg.IDType, defaults.IDType)
+	}
+}
+
+func TestLoadMissingExplicitPath(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "does-not-exist.yaml")); err == nil {
+		t.Fatal("Load with a missing explicit --config path should error, got nil")
+	}
+}
+
+func TestLoadMalformedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "entc.yaml")
+	if err := ioutil.WriteFile(path, []byte("not: [valid yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("Load with a malformed config file should error, got nil")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "entc.yaml")
+	content := `
+target: ent
+idtype: string
+profiles:
+  postgres-prod:
+    storage: [sql]
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load(%q): %v", path, err)
+	}
+	if cfg.Target != "ent" {
+		t.Errorf("Target = %q, want %q", cfg.Target, "ent")
+	}
+	if cfg.IDType != "string" {
+		t.Errorf("IDType = %q, want %q", cfg.IDType, "string")
+	}
+	if _, ok := cfg.Profiles["postgres-prod"]; !ok {
+		t.Errorf("Profiles = %+v, want a \"postgres-prod\" entry", cfg.Profiles)
+	}
+}
+
+func TestApply(t *testing.T) {
+	cfg := &Config{
+		Target: "ent",
+		IDType: "int",
+		Profiles: map[string]Profile{
+			"sqlite-tests": {Target: "ent/test", Storage: []string{"sql"}, IDType: "string"},
+		},
+	}
+	if out, err := cfg.Apply(""); err != nil || out != cfg {
+		t.Fatalf("Apply(\"\") = %+v, %v; want cfg unchanged", out, err)
+	}
+	out, err := cfg.Apply("sqlite-tests")
+	if err != nil {
+		t.Fatalf("Apply(%q): %v", "sqlite-tests", err)
+	}
+	if out.Target != "ent/test" || out.IDType != "string" {
+		t.Errorf("Apply(%q) = %+v, want Target=ent/test IDType=string", "sqlite-tests", out)
+	}
+	if _, err := cfg.Apply("unknown"); err == nil {
+		t.Fatal("Apply with an unknown profile should error, got nil")
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+// Package entgql provides an API for generating a gqlgen-compatible
+// GraphQL schema, resolver stubs and binding config from an ent schema
+// graph. It is used by the "entc gqlgen" command, but is also importable
+// so that users can drive the generation from their own entc.go codegen
+// driver.
+package entgql
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/facebookincubator/ent/entc/gen"
+	"github.com/facebookincubator/ent/schema/field"
+)
+
+// Config controls how the GraphQL schema, resolvers and gqlgen binding
+// config are generated for a graph.
+type Config struct {
+	// Target is the directory the schema, resolvers and gqlgen.yml
+	// are written to. Generate returns an error when it is empty; the
+	// "entc gqlgen" command defaults it to a "graph" directory next to
+	// the generated ent package.
+	Target string
+	// Relay, if true, adds the Node interface and the PageInfo type,
+	// turns the Query root's list fields into connections with
+	// cursor-based pagination arguments, and turns to-many edges into
+	// connection fields (without pagination arguments).
+	Relay bool
+}
+
+// Generate writes a gqlgen-compatible schema.graphql, resolver stubs and
+// a gqlgen.yml binding config for the given graph to cfg.Target.
+func Generate(graph *gen.Graph, cfg Config) error {
+	if cfg.Target == "" {
+		return fmt.Errorf("entgql: target directory is required")
+	}
+	if err := os.MkdirAll(cfg.Target, os.ModePerm); err != nil {
+		return fmt.Errorf("entgql: create target directory: %w", err)
+	}
+	b, err := newBinder(graph, cfg)
+	if err != nil {
+		return err
+	}
+	for name, tmpl := range map[string]*template.Template{
+		"schema.graphql": schemaTmpl,
+		"gqlgen.yml":     configTmpl,
+		"resolver.go":    resolverTmpl,
+	} {
+		buf := bytes.NewBuffer(nil)
+		if err := tmpl.Execute(buf, b); err != nil {
+			return fmt.Errorf("entgql: execute %s: %w", name, err)
+		}
+		target := filepath.Join(cfg.Target, name)
+		if name == "resolver.go" {
+			// Never overwrite hand-written resolver implementations.
+			if _, err := os.Stat(target); err == nil {
+				continue
+			}
+		}
+		if err := ioutil.WriteFile(target, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("entgql: write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// binder holds the information needed to render the schema, resolver
+// and gqlgen binding templates. It mirrors the binding model gqlgen
+// itself uses to map GraphQL types to Go types.
+type binder struct {
+	Package string
+	Relay   bool
+	// IDType is the Go type ent generated the node ids as (e.g. "int",
+	// "string"), used by resolverTmpl to convert the GraphQL string id
+	// argument back to the type the ent client methods expect.
+	IDType string
+	Types  []*gqlType
+}
+
+// gqlType is the GraphQL representation of a single ent.gen.Type,
+// along with the fully-qualified Go type gqlgen should bind it to.
+type gqlType struct {
+	Name   string
+	GoType string
+	Fields []*gqlField
+	Edges  []*gqlEdge
+}
+
+type gqlField struct {
+	Name    string
+	GQLType string
+}
+
+type gqlEdge struct {
+	Name   string
+	Type   string
+	Unique bool
+	Relay  bool
+}
+
+func newBinder(graph *gen.Graph, cfg Config) (*binder, error) {
+	idType := "int"
+	if graph.Config.IDType != nil {
+		idType = graph.Config.IDType.Type.String()
+	}
+	b := &binder{
+		Package: graph.Package,
+		Relay:   cfg.Relay,
+		IDType:  idType,
+	}
+	for _, n := range graph.Nodes {
+		t := &gqlType{
+			Name:   n.Name,
+			GoType: fmt.Sprintf("%s.%s", graph.Package, n.Name),
+		}
+		for _, f := range n.Fields {
+			gt, err := gqlScalar(f.Type.Type)
+			if err != nil {
+				return nil, fmt.Errorf("entgql: %s.%s: %w", n.Name, f.Name, err)
+			}
+			t.Fields = append(t.Fields, &gqlField{Name: f.Name, GQLType: gt})
+		}
+		for _, e := range n.Edges {
+			t.Edges = append(t.Edges, &gqlEdge{
+				Name:   e.Name,
+				Type:   e.Type.Name,
+				Unique: e.Unique,
+				Relay:  cfg.Relay && !e.Unique,
+			})
+		}
+		b.Types = append(b.Types, t)
+	}
+	return b, nil
+}
+
+// gqlScalar maps an ent field.Type to its GraphQL scalar representation.
+func gqlScalar(t field.Type) (string, error) {
+	switch t.String() {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64":
+		return "Int", nil
+	case "float32", "float64":
+		return "Float", nil
+	case "bool":
+		return "Boolean", nil
+	case "string", "[]byte":
+		return "String", nil
+	case "time.Time":
+		return "Time", nil
+	default:
+		return "String", nil
+	}
+}
+
+// schemaTmpl renders the GraphQL type/input/connection/root schema.
+var schemaTmpl = template.Must(template.New("schema.graphql").Parse(`
+{{- if .Relay }}
+interface Node {
+  id: ID!
+}
+
+type PageInfo {
+  hasNextPage: Boolean!
+  hasPreviousPage: Boolean!
+  startCursor: String
+  endCursor: String
+}
+{{- end }}
+{{ range .Types }}
+type {{ .Name }} {{ if $.Relay }}implements Node {{ end }}{
+  id: ID!
+{{- range .Fields }}
+  {{ .Name }}: {{ .GQLType }}
+{{- end }}
+{{- range .Edges }}
+  {{ .Name }}: {{ if .Unique }}{{ .Type }}{{ else if .Relay }}{{ .Type }}Connection!{{ else }}[{{ .Type }}!]!{{ end }}
+{{- end }}
+}
+
+input Create{{ .Name }}Input {
+{{- range .Fields }}
+  {{ .Name }}: {{ .GQLType }}
+{{- end }}
+}
+
+input Update{{ .Name }}Input {
+  id: ID!
+{{- range .Fields }}
+  {{ .Name }}: {{ .GQLType }}
+{{- end }}
+}
+{{- if $.Relay }}
+
+type {{ .Name }}Edge {
+  node: {{ .Name }}
+  cursor: String!
+}
+
+type {{ .Name }}Connection {
+  edges: [{{ .Name }}Edge]
+  pageInfo: PageInfo!
+  totalCount: Int!
+}
+{{- end }}
+{{ end }}
+type Query {
+{{- range .Types }}
+  {{ .Name }}(id: ID!): {{ .Name }}
+{{- if $.Relay }}
+  {{ .Name }}s(after: String, first: Int, before: String, last: Int): {{ .Name }}Connection!
+{{- else }}
+  {{ .Name }}s: [{{ .Name }}!]!
+{{- end }}
+{{- end }}
+}
+
+type Mutation {
+{{- range .Types }}
+  create{{ .Name }}(input: Create{{ .Name }}Input!): {{ .Name }}!
+  update{{ .Name }}(input: Update{{ .Name }}Input!): {{ .Name }}!
+  delete{{ .Name }}(id: ID!): Boolean!
+{{- end }}
+}
+`))
+
+// tmplFuncs are the extra helpers used by resolverTmpl, on top of the
+// funcs gen.Funcs already exposes to external templates.
+var tmplFuncs = template.FuncMap{
+	"pascal": pascal,
+}
+
+// pascal upper-cases the first rune of s, matching gqlgen's default
+// naming strategy for Go identifiers derived from GraphQL field names.
+func pascal(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// resolverTmpl renders the Query/Mutation root resolvers and, for every
+// type that has edges, a per-type field resolver -- the full set of
+// methods schemaTmpl's Query, Mutation and edge fields require -- all
+// wired to the ent client and to the generated entities' QueryX methods.
+var resolverTmpl = template.Must(template.New("resolver.go").Funcs(tmplFuncs).Parse(`// Code generated by entc, a copy of "resolver.go" that doesn't exist
+// yet. Edit freely; it will not be overwritten by future runs.
+
+package graph
+
+import (
+	"context"
+{{- if .Relay }}
+	"fmt"
+{{- end }}
+{{- if ne .IDType "string" }}
+	"strconv"
+{{- end }}
+
+	"{{ .Package }}"
+)
+
+// Resolver is the root GraphQL resolver. Every query and mutation below
+// reads and writes through it.
+type Resolver struct {
+	Client *ent.Client
+}
+
+// parseID converts the GraphQL string id argument to {{ .IDType }}, the
+// type ent generated this graph's node ids as.
+{{- if eq .IDType "int" }}
+func parseID(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+{{- else if eq .IDType "int64" }}
+func parseID(s string) (int64, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
+{{- else if eq .IDType "uint" }}
+func parseID(s string) (uint, error) {
+	v, err := strconv.ParseUint(s, 10, 64)
+	return uint(v), err
+}
+{{- else if eq .IDType "uint64" }}
+func parseID(s string) (uint64, error) {
+	return strconv.ParseUint(s, 10, 64)
+}
+{{- else }}
+func parseID(s string) (string, error) {
+	return s, nil
+}
+{{- end }}
+
+func (r *Resolver) Query() QueryResolver       { return &queryResolver{r} }
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+{{- range .Types }}
+{{- if .Edges }}
+func (r *Resolver) {{ .Name }}() {{ .Name }}Resolver { return &{{ .Name | pascal }}Resolver{r} }
+{{- end }}
+{{- end }}
+
+type queryResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+{{- range .Types }}
+{{- if .Edges }}
+type {{ .Name | pascal }}Resolver struct{ *Resolver }
+{{- end }}
+{{- end }}
+{{ range .Types }}
+{{ $t := . }}
+func (r *queryResolver) {{ .Name }}(ctx context.Context, id string) (*ent.{{ .Name }}, error) {
+	oid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.Client.{{ .Name }}.Get(ctx, oid)
+}
+{{ if $.Relay }}
+func (r *queryResolver) {{ .Name }}s(ctx context.Context, after *string, first *int, before *string, last *int) (*{{ .Name }}Connection, error) {
+	nodes, err := r.Client.{{ .Name }}.Query().All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	edges := make([]*{{ .Name }}Edge, len(nodes))
+	for i, n := range nodes {
+		edges[i] = &{{ .Name }}Edge{Node: n, Cursor: fmt.Sprint(n.ID)}
+	}
+	return &{{ .Name }}Connection{Edges: edges, TotalCount: len(nodes), PageInfo: &PageInfo{}}, nil
+}
+{{ else }}
+func (r *queryResolver) {{ .Name }}s(ctx context.Context) ([]*ent.{{ .Name }}, error) {
+	return r.Client.{{ .Name }}.Query().All(ctx)
+}
+{{ end }}
+func (r *mutationResolver) Create{{ .Name }}(ctx context.Context, input Create{{ .Name }}Input) (*ent.{{ .Name }}, error) {
+	create := r.Client.{{ .Name }}.Create()
+{{- range .Fields }}
+	if input.{{ .Name | pascal }} != nil {
+		create.Set{{ .Name | pascal }}(*input.{{ .Name | pascal }})
+	}
+{{- end }}
+	return create.Save(ctx)
+}
+
+func (r *mutationResolver) Update{{ .Name }}(ctx context.Context, input Update{{ .Name }}Input) (*ent.{{ .Name }}, error) {
+	oid, err := parseID(input.ID)
+	if err != nil {
+		return nil, err
+	}
+	update := r.Client.{{ .Name }}.UpdateOneID(oid)
+{{- range .Fields }}
+	if input.{{ .Name | pascal }} != nil {
+		update.Set{{ .Name | pascal }}(*input.{{ .Name | pascal }})
+	}
+{{- end }}
+	return update.Save(ctx)
+}
+
+func (r *mutationResolver) Delete{{ .Name }}(ctx context.Context, id string) (bool, error) {
+	oid, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+	if err := r.Client.{{ .Name }}.DeleteOneID(oid).Exec(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+{{ range .Edges }}
+func (r *{{ $t.Name | pascal }}Resolver) {{ .Name | pascal }}(ctx context.Context, obj *ent.{{ $t.Name }}) ({{ if .Unique }}*ent.{{ .Type }}{{ else if .Relay }}*{{ .Type }}Connection{{ else }}[]*ent.{{ .Type }}{{ end }}, error) {
+{{- if .Unique }}
+	return obj.Query{{ .Name | pascal }}().Only(ctx)
+{{- else if .Relay }}
+	nodes, err := obj.Query{{ .Name | pascal }}().All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	edges := make([]*{{ .Type }}Edge, len(nodes))
+	for i, n := range nodes {
+		edges[i] = &{{ .Type }}Edge{Node: n, Cursor: fmt.Sprint(n.ID)}
+	}
+	return &{{ .Type }}Connection{Edges: edges, TotalCount: len(nodes), PageInfo: &PageInfo{}}, nil
+{{- else }}
+	return obj.Query{{ .Name | pascal }}().All(ctx)
+{{- end }}
+}
+{{ end }}
+{{ end }}`))
+
+// configTmpl renders the gqlgen.yml binding config, mapping GraphQL
+// type names to fully-qualified Go types in the ent package.
+var configTmpl = template.Must(template.New("gqlgen.yml").Parse(`# Code generated by entc, DO NOT EDIT.
+
+schema:
+  - schema.graphql
+
+resolver:
+  layout: single-file
+  filename: resolver.go
+
+models:
+{{- if .Relay }}
+  Node:
+    model: github.com/99designs/gqlgen/graphql.ID
+{{- end }}
+{{- range .Types }}
+  {{ .Name }}:
+    model: {{ .GoType }}
+{{- end }}
+`))
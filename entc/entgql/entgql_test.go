@@ -0,0 +1,129 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package entgql
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/facebookincubator/ent/entc/gen"
+	"github.com/facebookincubator/ent/schema/field"
+)
+
+func TestPascal(t *testing.T) {
+	tests := map[string]string{
+		"":     "",
+		"id":   "Id",
+		"name": "Name",
+		"Name": "Name",
+	}
+	for in, want := range tests {
+		if got := pascal(in); got != want {
+			t.Errorf("pascal(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGqlScalar(t *testing.T) {
+	tests := []struct {
+		in   field.Type
+		want string
+	}{
+		{field.TypeInt, "Int"},
+		{field.TypeInt64, "Int"},
+		{field.TypeUint, "Int"},
+		{field.TypeUint64, "Int"},
+		{field.TypeString, "String"},
+	}
+	for _, tt := range tests {
+		got, err := gqlScalar(tt.in)
+		if err != nil {
+			t.Fatalf("gqlScalar(%v): %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("gqlScalar(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewBinderIDType(t *testing.T) {
+	tests := []struct {
+		name   string
+		idType *field.TypeInfo
+		want   string
+	}{
+		{name: "defaults to int", idType: nil, want: "int"},
+		{name: "reads the graph's configured id type", idType: &field.TypeInfo{Type: field.TypeString}, want: "string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := &gen.Graph{
+				Package: "example.com/ent",
+				Config:  gen.Config{IDType: tt.idType},
+				Nodes: []*gen.Type{
+					{Name: "User", Fields: []*gen.Field{{Name: "name", Type: &field.TypeInfo{Type: field.TypeString}}}},
+				},
+			}
+			b, err := newBinder(graph, Config{})
+			if err != nil {
+				t.Fatalf("newBinder: %v", err)
+			}
+			if b.IDType != tt.want {
+				t.Errorf("IDType = %q, want %q", b.IDType, tt.want)
+			}
+		})
+	}
+}
+
+// TestResolverTmplIDConversion renders resolverTmpl for a few id types
+// and checks the generated code converts the GraphQL string id through
+// parseID before handing it to the ent client, instead of passing it
+// straight through (the bug a prior fix here left in place).
+func TestResolverTmplIDConversion(t *testing.T) {
+	tests := []struct {
+		idType      string
+		wantStrconv bool
+		wantParse   string
+	}{
+		{idType: "int", wantStrconv: true, wantParse: "strconv.Atoi(s)"},
+		{idType: "int64", wantStrconv: true, wantParse: "strconv.ParseInt(s, 10, 64)"},
+		{idType: "uint64", wantStrconv: true, wantParse: "strconv.ParseUint(s, 10, 64)"},
+		{idType: "string", wantStrconv: false, wantParse: "return s, nil"},
+	}
+	b := &binder{
+		Package: "example.com/ent",
+		Types: []*gqlType{
+			{Name: "User", GoType: "example.com/ent.User", Fields: []*gqlField{{Name: "name", GQLType: "String"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.idType, func(t *testing.T) {
+			b.IDType = tt.idType
+			buf := bytes.NewBuffer(nil)
+			if err := resolverTmpl.Execute(buf, b); err != nil {
+				t.Fatalf("execute resolverTmpl: %v", err)
+			}
+			out := buf.String()
+			if strings.Contains(out, `"strconv"`) != tt.wantStrconv {
+				t.Errorf("IDType %q: strconv import present = %v, want %v", tt.idType, strings.Contains(out, `"strconv"`), tt.wantStrconv)
+			}
+			if !strings.Contains(out, tt.wantParse) {
+				t.Errorf("IDType %q: generated parseID missing %q, got:\n%s", tt.idType, tt.wantParse, out)
+			}
+			for _, call := range []string{
+				"oid, err := parseID(id)",
+				"oid, err := parseID(input.ID)",
+				"r.Client.User.Get(ctx, oid)",
+				"r.Client.User.UpdateOneID(oid)",
+				"r.Client.User.DeleteOneID(oid)",
+			} {
+				if !strings.Contains(out, call) {
+					t.Errorf("IDType %q: generated resolver missing %q, got:\n%s", tt.idType, call, out)
+				}
+			}
+		})
+	}
+}
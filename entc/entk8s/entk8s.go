@@ -0,0 +1,347 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+// Package entk8s generates Kubernetes CustomResourceDefinition
+// manifests (and the OpenAPI v3 schemas they embed) from an ent schema
+// graph, so a single ent schema can back both a SQL store and a
+// controller-runtime-compatible Kubernetes API.
+package entk8s
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/facebookincubator/ent/entc/gen"
+)
+
+// EdgeMode controls how ent edges are represented in the generated CRD.
+type EdgeMode string
+
+const (
+	// EdgeModeReference represents edges as references to the related
+	// object's name (a unique edge becomes a single string field, a
+	// O2M/M2M edge becomes an array of strings).
+	EdgeModeReference EdgeMode = "reference"
+	// EdgeModeEmbed represents edges as embedded objects/arrays of the
+	// related type's own schema.
+	EdgeModeEmbed EdgeMode = "embed"
+)
+
+// Config controls the CRD/OpenAPI generation for a graph.
+type Config struct {
+	// Target is the directory the CRD manifests are written to.
+	Target string
+	// Group and Version are used for types that don't declare their
+	// own GroupVersionKind annotation.
+	Group   string
+	Version string
+	// EdgeMode selects how edges are represented. Defaults to
+	// EdgeModeReference.
+	EdgeMode EdgeMode
+}
+
+// GroupVersionKind is a schema.Annotation that overrides the Kubernetes
+// group/version/kind generated for a single ent schema type:
+//
+//	func (User) Annotations() []schema.Annotation {
+//		return []schema.Annotation{
+//			entk8s.GroupVersionKind{Group: "ent.io", Version: "v1"},
+//		}
+//	}
+type GroupVersionKind struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+// Name implements the schema.Annotation interface.
+func (GroupVersionKind) Name() string {
+	return "K8sGVK"
+}
+
+// Generate writes one CRD manifest per node in the graph to cfg.Target.
+func Generate(graph *gen.Graph, cfg Config) error {
+	if cfg.Target == "" {
+		return fmt.Errorf("entk8s: target directory is required")
+	}
+	if cfg.EdgeMode == "" {
+		cfg.EdgeMode = EdgeModeReference
+	}
+	if err := os.MkdirAll(cfg.Target, os.ModePerm); err != nil {
+		return fmt.Errorf("entk8s: create target directory: %w", err)
+	}
+	for _, n := range graph.Nodes {
+		crd, err := newCRD(n, cfg)
+		if err != nil {
+			return fmt.Errorf("entk8s: %s: %w", n.Name, err)
+		}
+		buf := bytes.NewBuffer(nil)
+		if err := crdTmpl.Execute(buf, crd); err != nil {
+			return fmt.Errorf("entk8s: render %s: %w", n.Name, err)
+		}
+		name := fmt.Sprintf("%s_%s.yaml", strings.ToLower(crd.Group), strings.ToLower(crd.Plural))
+		if err := ioutil.WriteFile(filepath.Join(cfg.Target, name), buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("entk8s: write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// crd is the data passed to crdTmpl to render a single
+// CustomResourceDefinition manifest.
+type crd struct {
+	Group      string
+	Version    string
+	Kind       string
+	Plural     string
+	Properties []property
+}
+
+// property is a single field or edge rendered as an OpenAPI v3 schema
+// property.
+type property struct {
+	Name        string
+	Type        string
+	Format      string
+	Enum        []string
+	Items       string // set for array properties
+	Description string
+	// Properties holds the nested schema for an embedded edge
+	// (EdgeModeEmbed): the related type's own fields and edges,
+	// rendered under this property (or, for a to-many edge, under
+	// its Items) instead of a bare "object"/"array" placeholder.
+	Properties []property
+}
+
+func newCRD(n *gen.Type, cfg Config) (*crd, error) {
+	gvk := gvkOf(n, cfg)
+	c := &crd{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind,
+		Plural:  strings.ToLower(gvk.Kind) + "s",
+	}
+	for _, f := range n.Fields {
+		p, err := fieldProperty(f)
+		if err != nil {
+			return nil, err
+		}
+		c.Properties = append(c.Properties, p)
+	}
+	for _, e := range n.Edges {
+		p, err := edgeProperty(e, cfg.EdgeMode, map[string]bool{n.Name: true})
+		if err != nil {
+			return nil, err
+		}
+		c.Properties = append(c.Properties, p)
+	}
+	return c, nil
+}
+
+// gvkOf resolves the GroupVersionKind for n, falling back to cfg's
+// defaults and the type's own name for any field the annotation left
+// unset.
+func gvkOf(n *gen.Type, cfg Config) GroupVersionKind {
+	gvk := GroupVersionKind{Group: cfg.Group, Version: cfg.Version, Kind: n.Name}
+	raw, ok := n.Annotations[(GroupVersionKind{}).Name()]
+	if !ok {
+		return gvk
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return gvk
+	}
+	if v, ok := m["Group"].(string); ok && v != "" {
+		gvk.Group = v
+	}
+	if v, ok := m["Version"].(string); ok && v != "" {
+		gvk.Version = v
+	}
+	if v, ok := m["Kind"].(string); ok && v != "" {
+		gvk.Kind = v
+	}
+	return gvk
+}
+
+// fieldProperty maps an ent field to its JSON-Schema/OpenAPI v3
+// equivalent: its scalar type/format, and its enum values when the
+// field is a field.TypeEnum. ent's Min/Max/Match validators are plain
+// closures with no structured metadata on gen.Field, so they can't be
+// read back here and are intentionally not propagated to the CRD.
+func fieldProperty(f *gen.Field) (property, error) {
+	p := property{Name: f.Name}
+	switch {
+	case f.IsEnum():
+		p.Type, p.Enum = "string", f.EnumValues()
+	default:
+		switch f.Type.Type.String() {
+		case "int", "int8", "int16", "int32",
+			"uint", "uint8", "uint16", "uint32":
+			p.Type = "integer"
+		case "int64", "uint64":
+			p.Type, p.Format = "integer", "int64"
+		case "float32", "float64":
+			p.Type = "number"
+		case "bool":
+			p.Type = "boolean"
+		case "time.Time":
+			p.Type, p.Format = "string", "date-time"
+		case "[]byte":
+			p.Type, p.Format = "string", "byte"
+		case "string":
+			p.Type = "string"
+		default:
+			// field.TypeJSON and other opaque types are passed
+			// through as an unstructured object.
+			p.Type = "object"
+		}
+	}
+	return p, nil
+}
+
+// edgeProperty maps an ent edge to either a reference field/array or an
+// embedded object/array, depending on mode. In embed mode it recurses
+// into the related type's own fields and edges so the generated CRD
+// schema actually carries the related object's shape, not just a bare
+// "object"/"array" placeholder; visited tracks the type names already
+// on the current recursion path so a cycle (e.g. a self-referential or
+// mutually-referential edge) stops instead of recursing forever.
+func edgeProperty(e *gen.Edge, mode EdgeMode, visited map[string]bool) (property, error) {
+	p := property{Name: e.Name}
+	switch {
+	case mode == EdgeModeEmbed && e.Unique:
+		p.Type = "object"
+	case mode == EdgeModeEmbed:
+		p.Type, p.Items = "array", "object"
+	case e.Unique:
+		p.Type = "string" // reference to the related object's name
+	default:
+		p.Type, p.Items = "array", "string"
+		return p, nil
+	}
+	if mode != EdgeModeEmbed {
+		return p, nil
+	}
+	props, err := embeddedProperties(e.Type, mode, visited)
+	if err != nil {
+		return property{}, err
+	}
+	p.Properties = props
+	return p, nil
+}
+
+// embeddedProperties builds the nested property list for an embedded
+// edge's related type n, recursing into its own edges in turn. If n's
+// name is already on the current recursion path (visited), the cycle
+// is cut short by embedding no further nested properties rather than
+// recursing forever.
+func embeddedProperties(n *gen.Type, mode EdgeMode, visited map[string]bool) ([]property, error) {
+	if visited[n.Name] {
+		return nil, nil
+	}
+	seen := make(map[string]bool, len(visited)+1)
+	for k := range visited {
+		seen[k] = true
+	}
+	seen[n.Name] = true
+	var props []property
+	for _, f := range n.Fields {
+		p, err := fieldProperty(f)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, p)
+	}
+	for _, e := range n.Edges {
+		p, err := edgeProperty(e, mode, seen)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, p)
+	}
+	return props, nil
+}
+
+// propNode pairs a property with the YAML indent it renders at, so the
+// "property" template below can call itself for a nested Properties
+// list without text/template needing a way to do indent arithmetic.
+type propNode struct {
+	Prop   property
+	Indent string
+}
+
+var crdFuncs = template.FuncMap{
+	"node": func(p property, indent string) propNode {
+		return propNode{Prop: p, Indent: indent}
+	},
+}
+
+// crdTmpl renders a controller-runtime-compatible CustomResourceDefinition
+// manifest for a single ent schema type. The "property" template it
+// invokes renders a single schema property at .Indent, recursing into
+// .Prop.Properties (the related type's own fields/edges, for an
+// embedded edge) at one extra indent level -- under "properties" for a
+// to-one edge, under "items.properties" for a to-many edge.
+var crdTmpl = template.Must(template.New("crd.yaml").Funcs(crdFuncs).Parse(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: {{ .Plural }}.{{ .Group }}
+spec:
+  group: {{ .Group }}
+  names:
+    kind: {{ .Kind }}
+    plural: {{ .Plural }}
+  scope: Namespaced
+  versions:
+    - name: {{ .Version }}
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              properties:
+{{- range .Properties }}
+{{ template "property" (node . "                ") }}
+{{- end }}
+{{- define "property" }}
+{{ .Indent }}{{ .Prop.Name }}:
+{{ .Indent }}  type: {{ .Prop.Type }}
+{{- if .Prop.Format }}
+{{ .Indent }}  format: {{ .Prop.Format }}
+{{- end }}
+{{- if .Prop.Enum }}
+{{ .Indent }}  enum:
+{{- range .Prop.Enum }}
+{{ $.Indent }}    - {{ . }}
+{{- end }}
+{{- end }}
+{{- if eq .Prop.Items "object" }}
+{{ .Indent }}  items:
+{{ .Indent }}    type: object
+{{- if .Prop.Properties }}
+{{ .Indent }}    properties:
+{{- range .Prop.Properties }}
+{{ template "property" (node . (print $.Indent "      ")) }}
+{{- end }}
+{{- end }}
+{{- else if .Prop.Items }}
+{{ .Indent }}  items:
+{{ .Indent }}    type: {{ .Prop.Items }}
+{{- end }}
+{{- if and (eq .Prop.Type "object") .Prop.Properties }}
+{{ .Indent }}  properties:
+{{- range .Prop.Properties }}
+{{ template "property" (node . (print $.Indent "    ")) }}
+{{- end }}
+{{- end }}
+{{- end }}
+`))
@@ -0,0 +1,81 @@
+// Copyright 2019-present Facebook Inc. All rights reserved.
+// This source code is licensed under the Apache 2.0 license found
+// in the LICENSE file in the root directory of this source tree.
+
+package entk8s
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/facebookincubator/ent/entc/gen"
+)
+
+func TestGroupVersionKindName(t *testing.T) {
+	if got := (GroupVersionKind{}).Name(); got != "K8sGVK" {
+		t.Errorf("Name() = %q, want %q", got, "K8sGVK")
+	}
+}
+
+func TestGvkOf(t *testing.T) {
+	cfg := Config{Group: "ent.io", Version: "v1"}
+	n := &gen.Type{Name: "User"}
+	if gvk := gvkOf(n, cfg); gvk != (GroupVersionKind{Group: "ent.io", Version: "v1", Kind: "User"}) {
+		t.Errorf("gvkOf with no annotation = %+v, want the cfg defaults and the type's own name", gvk)
+	}
+	n.Annotations = map[string]interface{}{
+		(GroupVersionKind{}).Name(): map[string]interface{}{"Kind": "Account"},
+	}
+	if gvk := gvkOf(n, cfg); gvk.Kind != "Account" || gvk.Group != "ent.io" {
+		t.Errorf("gvkOf with a partial annotation = %+v, want Kind overridden and Group left at its default", gvk)
+	}
+}
+
+// TestCrdTmplEmbed renders crdTmpl directly off hand-built property
+// trees (bypassing edgeProperty/gen.Type) and checks that an embedded
+// edge's nested properties are actually emitted -- under "properties"
+// for a to-one edge and "items.properties" for a to-many edge -- not
+// just a bare "object"/"array" placeholder.
+func TestCrdTmplEmbed(t *testing.T) {
+	c := &crd{
+		Group: "ent.io", Version: "v1", Kind: "User", Plural: "users",
+		Properties: []property{
+			{Name: "name", Type: "string"},
+			{Name: "pet", Type: "object", Properties: []property{
+				{Name: "name", Type: "string"},
+			}},
+			{Name: "friends", Type: "array", Items: "object", Properties: []property{
+				{Name: "name", Type: "string"},
+			}},
+		},
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := crdTmpl.Execute(buf, c); err != nil {
+		t.Fatalf("execute crdTmpl: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"pet:\n", "type: object", // to-one embed
+		"friends:\n", "items:\n", // to-many embed
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered CRD missing %q, got:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "type: string"); n != 3 {
+		t.Errorf("expected the nested \"name\" field to appear once per type (3 total: User, Pet, Friend), got %d:\n%s", n, out)
+	}
+}
+
+func TestEmbeddedPropertiesCycle(t *testing.T) {
+	user := &gen.Type{Name: "User"}
+	user.Edges = []*gen.Edge{{Name: "self", Type: user}}
+	props, err := embeddedProperties(user, EdgeModeEmbed, map[string]bool{"User": true})
+	if err != nil {
+		t.Fatalf("embeddedProperties: %v", err)
+	}
+	if props != nil {
+		t.Errorf("embeddedProperties of an already-visited type should stop recursing, got %+v", props)
+	}
+}